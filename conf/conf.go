@@ -0,0 +1,82 @@
+// Package conf parses command-line configuration for kpick.
+package conf
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/loganstone/kpick/file"
+)
+
+// KoreanPatternForRegex is the default regular expression used to detect
+// Korean text (Hangul Compatibility Jamo and Hangul Syllables).
+const KoreanPatternForRegex = `[\x{3131}-\x{318E}\x{AC00}-\x{D7A3}]`
+
+// Options holds the command-line configuration for kpick.
+type Options struct {
+	DirToFind      string
+	FileExtToScan  string
+	SkipPaths      string
+	IgnorePattern  string
+	Patterns       map[string]string
+	Format         string
+	Cpuprofile     string
+	Memprofile     string
+	MaxFileSize    int64
+	MaxLineBytes   int
+	ContextBefore  int
+	ContextAfter   int
+	Concurrency    int
+	FollowSymlinks bool
+	RespectIgnore  bool
+	SkipBinary     bool
+	Interactive    bool
+	Verbose        bool
+	ErrorOnly      bool
+}
+
+// patternFlag collects repeated "-pattern name=regex" flags into a map.
+type patternFlag map[string]string
+
+func (p patternFlag) String() string {
+	return fmt.Sprintf("%v", map[string]string(p))
+}
+
+func (p patternFlag) Set(value string) error {
+	name, pattern, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("invalid -pattern %q, want name=regex", value)
+	}
+	p[name] = pattern
+	return nil
+}
+
+// Opts parses the command-line flags and returns the resulting Options.
+func Opts() Options {
+	var o Options
+	flag.StringVar(&o.DirToFind, "dir", ".", "directory to find files in")
+	flag.StringVar(&o.FileExtToScan, "ext", "go", "file extension to scan")
+	flag.StringVar(&o.SkipPaths, "skip", "", "comma separated regex patterns of paths to skip")
+	flag.StringVar(&o.IgnorePattern, "ignore", "", "regex pattern for lines to ignore")
+	patterns := patternFlag{}
+	flag.Var(patterns, "pattern", "additional named regex pattern as name=regex (repeatable)")
+	flag.StringVar(&o.Format, "format", "plain", "output format: plain, json, sarif")
+	flag.StringVar(&o.Cpuprofile, "cpuprofile", "", "write cpu profile to file")
+	flag.StringVar(&o.Memprofile, "memprofile", "", "write memory profile to file")
+	flag.Int64Var(&o.MaxFileSize, "max-size", 0, "skip files larger than this many bytes (0 means no limit)")
+	flag.IntVar(&o.MaxLineBytes, "max-line-bytes", file.DefaultMaxLineBytes, "error instead of scanning a line longer than this many bytes")
+	flag.IntVar(&o.ContextBefore, "before-context", 0, "print this many lines of context before a match, a-la grep -B")
+	flag.IntVar(&o.ContextAfter, "after-context", 0, "print this many lines of context after a match, a-la grep -A")
+	flag.IntVar(&o.Concurrency, "concurrency", runtime.NumCPU(), "number of files to scan at once")
+	flag.BoolVar(&o.FollowSymlinks, "follow-symlinks", false, "scan files reached via symlinks")
+	flag.BoolVar(&o.RespectIgnore, "respect-gitignore", true, "skip files matched by .gitignore/.kpickignore")
+	flag.BoolVar(&o.SkipBinary, "skip-binary", true, "skip files that look binary")
+	flag.BoolVar(&o.Interactive, "interactive", false, "ask for confirmation before scanning")
+	flag.BoolVar(&o.Verbose, "verbose", false, "print verbose scanning logs")
+	flag.BoolVar(&o.ErrorOnly, "error-only", false, "print only scanning errors")
+	flag.Parse()
+	o.Patterns = patterns
+	return o
+}
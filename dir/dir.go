@@ -0,0 +1,28 @@
+// Package dir finds files to scan under a root directory.
+package dir
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// Check returns an error if dirPath does not exist or is not a directory.
+func Check(dirPath string) error {
+	info, err := os.Stat(dirPath)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("[%s] is not a directory", dirPath)
+	}
+	return nil
+}
+
+// Find walks root and returns the paths of files whose extension matches
+// ext and whose path does not match any of skipPaths.
+func Find(root, ext string, skipPaths []*regexp.Regexp) ([]string, error) {
+	w := NewWalker(root, ExtensionFilter(ext), SkipPathFilter(skipPaths))
+	return w.Walk()
+}
@@ -0,0 +1,196 @@
+package dir
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ExtensionFilter selects files whose extension (without the leading dot)
+// equals ext.
+func ExtensionFilter(ext string) SelectFunc {
+	return func(path string, fi os.FileInfo) bool {
+		return strings.TrimPrefix(filepath.Ext(path), ".") == ext
+	}
+}
+
+// SkipPathFilter selects files whose path does not match any of skip.
+func SkipPathFilter(skip []*regexp.Regexp) SelectFunc {
+	return func(path string, fi os.FileInfo) bool {
+		for _, re := range skip {
+			if re.MatchString(path) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// MaxSizeFilter selects files no larger than maxBytes.
+func MaxSizeFilter(maxBytes int64) SelectFunc {
+	return func(path string, fi os.FileInfo) bool {
+		return fi.Size() <= maxBytes
+	}
+}
+
+// SymlinkFilter selects symlinks only when follow is true; non-symlinks
+// are always selected.
+func SymlinkFilter(follow bool) SelectFunc {
+	return func(path string, fi os.FileInfo) bool {
+		if fi.Mode()&os.ModeSymlink == 0 {
+			return true
+		}
+		return follow
+	}
+}
+
+// sniffBytes is the amount of a file's head read to guess whether it is
+// binary, matching the common "first 8KB" convention (git uses the same
+// heuristic).
+const sniffBytes = 8192
+
+// BinaryFilter selects files whose first sniffBytes bytes contain no NUL
+// byte, i.e. it rejects files that look binary.
+func BinaryFilter() SelectFunc {
+	return func(path string, fi os.FileInfo) bool {
+		f, err := os.Open(path)
+		if err != nil {
+			return false
+		}
+		defer f.Close()
+
+		buf := make([]byte, sniffBytes)
+		n, err := f.Read(buf)
+		if err != nil && n == 0 {
+			return true
+		}
+		return !bytes.Contains(buf[:n], []byte{0})
+	}
+}
+
+// ignorePattern is one parsed line of a .gitignore-style file.
+type ignorePattern struct {
+	negate  bool
+	pattern string
+}
+
+// GitignoreFilter reads the gitignore-style pattern file at path (e.g.
+// ".gitignore" or ".kpickignore") and returns a SelectFunc that rejects
+// paths matching the last pattern that matched, honouring "!" negation.
+// Patterns are matched against path relative to root using filepath.Match
+// semantics; "**" is treated as matching any number of path segments.
+func GitignoreFilter(root, path string) (SelectFunc, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return func(string, os.FileInfo) bool { return true }, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []ignorePattern
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		p := ignorePattern{pattern: line}
+		if strings.HasPrefix(p.pattern, "!") {
+			p.negate = true
+			p.pattern = p.pattern[1:]
+		}
+		p.pattern = strings.TrimPrefix(p.pattern, "/")
+		p.pattern = strings.TrimSuffix(p.pattern, "/")
+		patterns = append(patterns, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return func(p string, fi os.FileInfo) bool {
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			rel = p
+		}
+		rel = filepath.ToSlash(rel)
+
+		ignored := false
+		for _, ip := range patterns {
+			if matchGitignorePattern(ip.pattern, rel) {
+				ignored = !ip.negate
+			}
+		}
+		return !ignored
+	}, nil
+}
+
+// matchGitignorePattern reports whether rel matches a gitignore-style
+// pattern, expanding "**" to match any number of path segments (including
+// none) and falling back to filepath.Match per segment otherwise.
+func matchGitignorePattern(pattern, rel string) bool {
+	if !strings.Contains(pattern, "**") {
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(rel)); ok {
+			return true
+		}
+		return matchesAnySegment(pattern, rel)
+	}
+
+	re, err := regexp.Compile("^" + globToRegexp(pattern) + "$")
+	if err != nil {
+		return false
+	}
+	return re.MatchString(rel)
+}
+
+// matchesAnySegment reports whether pattern names a path segment of rel at
+// any depth, the way a slash-free, wildcard-free gitignore pattern (e.g.
+// "vendor") ignores a directory or file of that name wherever it occurs,
+// not just at the root of the walked tree.
+func matchesAnySegment(pattern, rel string) bool {
+	for _, segment := range strings.Split(rel, "/") {
+		if segment == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// globToRegexp converts a gitignore-style glob (supporting "**") to a
+// regexp fragment without anchors; the caller adds "^" and "$".
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	parts := strings.Split(glob, "**")
+	for i, part := range parts {
+		if i > 0 {
+			if strings.HasPrefix(part, "/") {
+				// "**/" may also stand for no segments at all.
+				b.WriteString("(?:.*/)?")
+				part = part[1:]
+			} else {
+				b.WriteString(".*")
+			}
+		}
+		for _, r := range part {
+			switch r {
+			case '*':
+				b.WriteString("[^/]*")
+			case '?':
+				b.WriteString("[^/]")
+			case '.', '(', ')', '+', '|', '^', '$':
+				b.WriteString(regexp.QuoteMeta(string(r)))
+			default:
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package dir
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchGitignorePattern(t *testing.T) {
+	cases := []struct {
+		name    string
+		pattern string
+		rel     string
+		want    bool
+	}{
+		{"exact file match", "foo.txt", "foo.txt", true},
+		{"no match", "foo.txt", "bar.txt", false},
+		{"bare name matches nested file", "vendor", "a/vendor/pkg.go", true},
+		{"star glob matches within a segment", "*.log", "logs/app.log", true},
+		{"star glob does not cross a segment", "*.log", "logs/app.log/extra", false},
+		{"double-star matches any depth", "**/build", "a/b/build", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := matchGitignorePattern(tc.pattern, tc.rel); got != tc.want {
+				t.Errorf("matchGitignorePattern(%q, %q) = %v, want %v", tc.pattern, tc.rel, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGitignoreFilterTrailingSlashDirectoryPattern(t *testing.T) {
+	root := t.TempDir()
+	ignoreFile := filepath.Join(root, ".gitignore")
+	if err := os.WriteFile(ignoreFile, []byte("dist/\nnode_modules/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	filter, err := GitignoreFilter(root, ignoreFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		path string
+		want bool
+	}{
+		{filepath.Join(root, "dist", "bundle.js"), false},
+		{filepath.Join(root, "node_modules", "a", "index.js"), false},
+		{filepath.Join(root, "src", "main.go"), true},
+	}
+
+	for _, tc := range cases {
+		if got := filter(tc.path, nil); got != tc.want {
+			t.Errorf("filter(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestGitignoreFilterNegation(t *testing.T) {
+	root := t.TempDir()
+	ignoreFile := filepath.Join(root, ".gitignore")
+	contents := "*.log\n!keep.log\n"
+	if err := os.WriteFile(ignoreFile, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	filter, err := GitignoreFilter(root, ignoreFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if filter(filepath.Join(root, "debug.log"), nil) {
+		t.Error("debug.log should be ignored by *.log")
+	}
+	if !filter(filepath.Join(root, "keep.log"), nil) {
+		t.Error("keep.log should be un-ignored by the negated pattern")
+	}
+}
+
+func TestGitignoreFilterMissingFile(t *testing.T) {
+	root := t.TempDir()
+	filter, err := GitignoreFilter(root, filepath.Join(root, ".gitignore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !filter(filepath.Join(root, "anything.go"), nil) {
+		t.Error("a missing ignore file should select everything")
+	}
+}
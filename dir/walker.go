@@ -0,0 +1,120 @@
+package dir
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SelectFunc reports whether path should be included in a Walker's results.
+// It mirrors the SelectFilter refactor in restic: a Walker is configured
+// with one SelectFunc, and callers compose multiple criteria with And.
+type SelectFunc func(path string, fi os.FileInfo) bool
+
+// And combines fns into a single SelectFunc that requires all of them to
+// select a path (AND semantics). A nil fns yields a SelectFunc that
+// selects everything.
+func And(fns ...SelectFunc) SelectFunc {
+	return func(path string, fi os.FileInfo) bool {
+		for _, fn := range fns {
+			if fn == nil {
+				continue
+			}
+			if !fn(path, fi) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or combines fns into a single SelectFunc that selects a path if any one
+// of them does (OR semantics). A nil or empty fns yields a SelectFunc that
+// selects nothing.
+func Or(fns ...SelectFunc) SelectFunc {
+	return func(path string, fi os.FileInfo) bool {
+		for _, fn := range fns {
+			if fn != nil && fn(path, fi) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Walker walks Root and collects the paths of regular files selected by
+// Select. FollowSymlinks controls whether symlinked files are considered
+// at all; when false (the default) they are skipped before Select ever
+// sees them.
+type Walker struct {
+	Root           string
+	Select         SelectFunc
+	FollowSymlinks bool
+}
+
+// NewWalker returns a Walker rooted at root whose Select requires every one
+// of selectFns to select a path.
+func NewWalker(root string, selectFns ...SelectFunc) *Walker {
+	return &Walker{Root: root, Select: And(selectFns...)}
+}
+
+// Walk walks w.Root and returns the paths of every regular file w.Select
+// selects. When FollowSymlinks is set, a symlinked directory is traversed
+// too (filepath.Walk never does this, since it always lstats); visited
+// directories are tracked by their resolved path so a symlink cycle can't
+// send Walk into an infinite loop.
+func (w *Walker) Walk() ([]string, error) {
+	symlinks := SymlinkFilter(w.FollowSymlinks)
+	visited := map[string]bool{}
+
+	var found []string
+	var walkDir func(dir string) error
+	walkDir = func(dir string) error {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			fi, err := entry.Info()
+			if err != nil {
+				return err
+			}
+
+			if fi.Mode()&os.ModeSymlink != 0 && w.FollowSymlinks {
+				if target, err := os.Stat(path); err == nil && target.IsDir() {
+					real, err := filepath.EvalSymlinks(path)
+					if err != nil || visited[real] {
+						continue
+					}
+					visited[real] = true
+					if err := walkDir(path); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+
+			if fi.IsDir() {
+				if err := walkDir(path); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if !symlinks(path, fi) {
+				continue
+			}
+
+			if w.Select == nil || w.Select(path, fi) {
+				found = append(found, path)
+			}
+		}
+		return nil
+	}
+
+	if err := walkDir(w.Root); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
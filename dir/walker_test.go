@@ -0,0 +1,104 @@
+package dir
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestWalkerFollowSymlinks(t *testing.T) {
+	root := t.TempDir()
+	realDir := filepath.Join(root, "real")
+	if err := os.Mkdir(realDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(realDir, "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(realDir, filepath.Join(root, "link")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	t.Run("not followed by default", func(t *testing.T) {
+		w := NewWalker(root)
+		found, err := w.Walk()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(found); got != 1 {
+			t.Errorf("found %d files, want 1 (only real/a.txt)", got)
+		}
+	})
+
+	t.Run("followed when enabled", func(t *testing.T) {
+		w := NewWalker(root)
+		w.FollowSymlinks = true
+		found, err := w.Walk()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(found); got != 2 {
+			t.Errorf("found %d files, want 2 (real/a.txt and link/a.txt)", got)
+		}
+	})
+}
+
+func TestWalkerSymlinkCycleDoesNotInfiniteLoop(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "a")
+	if err := os.Mkdir(a, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(a, "file.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(root, filepath.Join(a, "cycle")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	w := NewWalker(root)
+	w.FollowSymlinks = true
+
+	done := make(chan struct{})
+	var found []string
+	var err error
+	go func() {
+		found, err = w.Walk()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Walk did not return, likely stuck in a symlink cycle")
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(found)
+	if len(found) == 0 {
+		t.Error("found no files, want at least a/file.txt")
+	}
+}
+
+func TestWalkerSelectFuncComposition(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "b.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewWalker(root, ExtensionFilter("go"))
+	found, err := w.Walk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || filepath.Base(found[0]) != "a.go" {
+		t.Errorf("found %v, want only a.go", found)
+	}
+}
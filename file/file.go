@@ -2,11 +2,12 @@ package file
 
 import (
 	"bufio"
-	"container/heap"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"os"
 	"regexp"
+	"runtime"
 	"sort"
 	"sync"
 )
@@ -17,21 +18,68 @@ type BeforeScanFunc func(path string)
 // AfterScanFunc .
 type AfterScanFunc func(path string)
 
-// File finds and stores the line matching the specified regular expression.
+// DefaultMaxLineBytes is the line size Scan enforces when ScanOptions
+// doesn't set one. It exists so a single-line minified bundle can't be
+// read entirely into memory before Scan notices anything is wrong.
+const DefaultMaxLineBytes = 1 << 20 // 1 MiB
+
+// PatternSet is the set of named patterns a File is scanned against, plus
+// one shared Ignore pattern applied before any of them.
+type PatternSet struct {
+	Patterns map[string]*regexp.Regexp
+	Ignore   *regexp.Regexp
+}
+
+// ScanOptions configures how ScanFiles reads and matches against each file.
+type ScanOptions struct {
+	Patterns      PatternSet
+	MaxLineBytes  int
+	ContextBefore int
+	ContextAfter  int
+	Concurrency   int
+}
+
+// Match is a single line that matched one or more named patterns, along
+// with the surrounding context lines requested via
+// ScanOptions.ContextBefore/After. Column and ByteOffset locate the start
+// of the earliest pattern match on the line: Column is the 1-based byte
+// column within the line, ByteOffset is the 0-based byte offset from the
+// start of the file.
+type Match struct {
+	Line       int
+	Column     int
+	ByteOffset int64
+	Text       []byte
+	Names      []string
+	Before     [][]byte
+	After      [][]byte
+}
+
+// File finds and stores the lines matching the specified patterns.
 type File struct {
-	path         string
-	matchRegex   *regexp.Regexp
-	ignoreRegex  *regexp.Regexp
-	matchedLines map[int][]byte
-	scanError    error
+	path          string
+	patterns      PatternSet
+	maxLineBytes  int
+	contextBefore int
+	contextAfter  int
+	matches       map[int]Match
+	scanError     error
 }
 
-// Scan checks the contents of the file line by line to see
-// if it matches the regular expression.
-// When it finds a line that matches the regular expression,
-// it stores the contents of the line with the line number.
+// initialScanBufferBytes is the largest initial bufio.Scanner buffer Scan
+// will allocate up front. bufio.Scanner's effective max token size is
+// max(cap(initialBuf), maxLineBytes), so the initial buffer must never be
+// sized above maxLineBytes itself or a small configured limit would be
+// silently ignored.
+const initialScanBufferBytes = 64 * 1024
+
+// Scan reads the file line by line looking for lines that match any of
+// patterns.Patterns and don't match patterns.Ignore. It uses a
+// bufio.Scanner bounded by maxLineBytes so a single pathologically long
+// line (e.g. a minified JS bundle) errors out instead of being buffered
+// into memory in full.
 func (f *File) Scan() {
-	if f.matchRegex == nil {
+	if len(f.patterns.Patterns) == 0 {
 		return
 	}
 
@@ -43,38 +91,97 @@ func (f *File) Scan() {
 
 	defer file.Close()
 
-	reader := bufio.NewReader(file)
-	line := []byte{}
-	var lineNumber int
+	maxLineBytes := f.maxLineBytes
+	if maxLineBytes <= 0 {
+		maxLineBytes = DefaultMaxLineBytes
+	}
 
-	for {
-		chunk, isPrefix, err := reader.ReadLine()
-		if err != nil {
-			if err != io.EOF {
-				f.scanError = err
-			}
-			break
+	initialBufferBytes := maxLineBytes
+	if initialBufferBytes > initialScanBufferBytes {
+		initialBufferBytes = initialScanBufferBytes
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, initialBufferBytes), maxLineBytes)
+
+	// bufio.ScanLines strips a trailing "\r\n" or "\n" from each token
+	// without reporting how many bytes that was, so len(token)+1 undercounts
+	// CRLF lines by one byte per line. Wrap it to capture the real advance.
+	var tokenBytes int
+	scanner.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		advance, token, err = bufio.ScanLines(data, atEOF)
+		tokenBytes = advance
+		return
+	})
+
+	var offset int64
+	var lines [][]byte
+	var lineOffsets []int64
+	for scanner.Scan() {
+		lineOffsets = append(lineOffsets, offset)
+		line := scanner.Bytes()
+		offset += int64(tokenBytes)
+		lines = append(lines, append([]byte{}, line...))
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			f.scanError = fmt.Errorf("[%s] line %d exceeds max line size of %d bytes", f.path, len(lines)+1, maxLineBytes)
+			return
 		}
+		f.scanError = err
+		return
+	}
 
-		line = append(line, chunk...)
-		if isPrefix {
-			// NOTE(hs.lee): 줄 읽기가 다 끝나지 않았음. line 유지
+	for i, line := range lines {
+		if f.patterns.Ignore != nil && f.patterns.Ignore.Match(line) {
 			continue
 		}
 
-		// NOTE(hs.lee): 줄 읽기가 끝남
-		lineNumber++
-		if f.ignoreRegex != nil && f.ignoreRegex.Match(line) {
-			line = []byte{}
+		var names []string
+		matchStart := -1
+		for name, re := range f.patterns.Patterns {
+			loc := re.FindIndex(line)
+			if loc == nil {
+				continue
+			}
+			names = append(names, name)
+			if matchStart == -1 || loc[0] < matchStart {
+				matchStart = loc[0]
+			}
+		}
+		if len(names) == 0 {
 			continue
 		}
+		sort.Strings(names)
 
-		if f.matchRegex.Match(line) {
-			f.matchedLines[lineNumber] = line
+		lineNumber := i + 1
+		f.matches[lineNumber] = Match{
+			Line:       lineNumber,
+			Column:     matchStart + 1,
+			ByteOffset: lineOffsets[i] + int64(matchStart),
+			Text:       line,
+			Names:      names,
+			Before:     contextLines(lines, i-f.contextBefore, i),
+			After:      contextLines(lines, i+1, i+1+f.contextAfter),
 		}
+	}
+}
 
-		line = []byte{}
+// contextLines returns a copy of lines[from:to], clamped to lines' bounds.
+func contextLines(lines [][]byte, from, to int) [][]byte {
+	if from < 0 {
+		from = 0
 	}
+	if to > len(lines) {
+		to = len(lines)
+	}
+	if from >= to {
+		return nil
+	}
+
+	out := make([][]byte, to-from)
+	copy(out, lines[from:to])
+	return out
 }
 
 // Path returns a file path.
@@ -87,29 +194,31 @@ func (f *File) Error() error {
 	return f.scanError
 }
 
-// MatchedLines returns the result of Scan.
-func (f *File) MatchedLines() map[int][]byte {
-	return f.matchedLines
+// MatchedLines returns the result of Scan, keyed by line number.
+func (f *File) MatchedLines() map[int]Match {
+	return f.matches
 }
 
-func (f *File) printMatchedLines() {
-	lineNumbers := make([]int, len(f.matchedLines))
+// sortedMatchLines returns the keys of matches in ascending order.
+func sortedMatchLines(matches map[int]Match) []int {
+	lineNumbers := make([]int, len(matches))
 	var i int
-	for lineNumber := range f.matchedLines {
+	for lineNumber := range matches {
 		lineNumbers[i] = lineNumber
 		i++
 	}
 
 	sort.Ints(lineNumbers)
-	for _, lineNumber := range lineNumbers {
-		lineText, _ := f.matchedLines[lineNumber]
-		fmt.Printf("%d: %s\n", lineNumber, lineText)
-	}
+	return lineNumbers
 }
 
 // Heap is a data type for sorting the file list in ascending order by name.
 type Heap []*File
 
+// SortedFiles is Heap under the name used by callers that only care about
+// the ascending, by-path ordering it provides.
+type SortedFiles = Heap
+
 func (h Heap) Len() int {
 	return len(h)
 }
@@ -136,39 +245,68 @@ func (h *Heap) Pop() interface{} {
 	return element
 }
 
-// Print is prints data of Heap.
-func (h Heap) Print() {
-	for h.Len() > 0 {
-		f, ok := heap.Pop(&h).(*File)
-		if ok {
-			fmt.Println(f.Path())
-			f.printMatchedLines()
-		}
+// newScanFile builds the File that will scan path according to opts.
+func newScanFile(path string, opts ScanOptions) *File {
+	return &File{
+		path:          path,
+		patterns:      opts.Patterns,
+		maxLineBytes:  opts.MaxLineBytes,
+		contextBefore: opts.ContextBefore,
+		contextAfter:  opts.ContextAfter,
+		matches:       map[int]Match{},
 	}
 }
 
-// ScanFiles .
-func ScanFiles(filePaths []string, m, ig *regexp.Regexp,
+// ScanFiles scans filePaths over a worker pool bounded by opts.Concurrency
+// (default runtime.NumCPU()), calling beforeFn and afterFn around each
+// file's Scan. Results stream back on the returned channel as they
+// complete, so memory use doesn't grow with len(filePaths). ctx
+// cancellation stops dispatching new files and abandons in-flight ones
+// still waiting to be read off the channel.
+func ScanFiles(ctx context.Context, filePaths []string, opts ScanOptions,
 	beforeFn BeforeScanFunc, afterFn AfterScanFunc) <-chan *File {
-	cp := make(chan *File)
-
-	var wg sync.WaitGroup
-	wg.Add(len(filePaths))
-
-	for _, filePath := range filePaths {
-		go func(filePath string) {
-			defer wg.Done()
-			beforeFn(filePath)
-			f := &File{filePath, m, ig, map[int][]byte{}, nil}
-			f.Scan()
-			afterFn(filePath)
-			cp <- f
-		}(filePath)
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
 	}
 
+	cp := make(chan *File, concurrency)
+	sem := make(chan struct{}, concurrency)
+
 	go func() {
+		defer close(cp)
+
+		var wg sync.WaitGroup
+	dispatch:
+		for _, filePath := range filePaths {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				break dispatch
+			}
+
+			wg.Add(1)
+			go func(filePath string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				beforeFn(filePath)
+				f := newScanFile(filePath, opts)
+				if ctx.Err() != nil {
+					f.scanError = ctx.Err()
+				} else {
+					f.Scan()
+				}
+				afterFn(filePath)
+
+				select {
+				case cp <- f:
+				case <-ctx.Done():
+				}
+			}(filePath)
+		}
 		wg.Wait()
-		close(cp)
 	}()
+
 	return cp
 }
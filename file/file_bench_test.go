@@ -0,0 +1,97 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+// benchFiles writes n small files containing a few lines of plain text
+// plus one Korean-matching line apiece, and returns their paths.
+func benchFiles(b *testing.B, n int) []string {
+	b.Helper()
+
+	dir := b.TempDir()
+	paths := make([]string, n)
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("file%d.go", i))
+		contents := "package main\n\nfunc main() {}\n// 한글 주석\n"
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			b.Fatal(err)
+		}
+		paths[i] = path
+	}
+	return paths
+}
+
+func benchScanOptions() ScanOptions {
+	return ScanOptions{
+		Patterns: PatternSet{
+			Patterns: map[string]*regexp.Regexp{
+				"korean": regexp.MustCompile(`[\x{3131}-\x{318E}\x{AC00}-\x{D7A3}]`),
+			},
+		},
+	}
+}
+
+// scanFilesUnbounded is the goroutine-per-file approach ScanFiles replaced:
+// no concurrency cap, so a large tree spawns as many goroutines as files.
+// It's kept here only so BenchmarkScanFilesUnbounded can measure what the
+// worker pool in ScanFiles improves on.
+func scanFilesUnbounded(ctx context.Context, filePaths []string, opts ScanOptions) <-chan *File {
+	cp := make(chan *File, len(filePaths))
+
+	go func() {
+		defer close(cp)
+
+		var wg sync.WaitGroup
+		for _, filePath := range filePaths {
+			wg.Add(1)
+			go func(filePath string) {
+				defer wg.Done()
+				f := newScanFile(filePath, opts)
+				if ctx.Err() != nil {
+					f.scanError = ctx.Err()
+				} else {
+					f.Scan()
+				}
+				cp <- f
+			}(filePath)
+		}
+		wg.Wait()
+	}()
+
+	return cp
+}
+
+// BenchmarkScanFiles measures the bounded worker pool over a large tree.
+func BenchmarkScanFiles(b *testing.B) {
+	paths := benchFiles(b, 5000)
+	opts := benchScanOptions()
+	noop := func(string) {}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for f := range ScanFiles(context.Background(), paths, opts, noop, noop) {
+			_ = f
+		}
+	}
+}
+
+// BenchmarkScanFilesUnbounded measures the goroutine-per-file approach
+// ScanFiles replaced, over the same tree, for comparison.
+func BenchmarkScanFilesUnbounded(b *testing.B) {
+	paths := benchFiles(b, 5000)
+	opts := benchScanOptions()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for f := range scanFilesUnbounded(context.Background(), paths, opts) {
+			_ = f
+		}
+	}
+}
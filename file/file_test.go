@@ -0,0 +1,137 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func scanFile(t *testing.T, contents string, opts ScanOptions) *File {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.txt")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newScanFile(path, opts)
+	f.Scan()
+	return f
+}
+
+func TestFileScanContextLines(t *testing.T) {
+	cases := []struct {
+		name          string
+		contents      string
+		contextBefore int
+		contextAfter  int
+		wantBefore    []string
+		wantAfter     []string
+	}{
+		{
+			name:          "no context requested",
+			contents:      "one\ntwo\nmatch\nfour\nfive\n",
+			contextBefore: 0,
+			contextAfter:  0,
+			wantBefore:    nil,
+			wantAfter:     nil,
+		},
+		{
+			name:          "context within bounds",
+			contents:      "one\ntwo\nmatch\nfour\nfive\n",
+			contextBefore: 2,
+			contextAfter:  2,
+			wantBefore:    []string{"one", "two"},
+			wantAfter:     []string{"four", "five"},
+		},
+		{
+			name:          "context clamped at file boundaries",
+			contents:      "match\ntwo\nthree\n",
+			contextBefore: 3,
+			contextAfter:  3,
+			wantBefore:    nil,
+			wantAfter:     []string{"two", "three"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := ScanOptions{
+				Patterns: PatternSet{
+					Patterns: map[string]*regexp.Regexp{"m": regexp.MustCompile("match")},
+				},
+				ContextBefore: tc.contextBefore,
+				ContextAfter:  tc.contextAfter,
+			}
+			f := scanFile(t, tc.contents, opts)
+			if f.Error() != nil {
+				t.Fatalf("unexpected scan error: %v", f.Error())
+			}
+
+			var m Match
+			for _, match := range f.MatchedLines() {
+				m = match
+			}
+			if got := joinLines(m.Before); got != strings.Join(tc.wantBefore, "\n") {
+				t.Errorf("Before = %q, want %q", got, strings.Join(tc.wantBefore, "\n"))
+			}
+			if got := joinLines(m.After); got != strings.Join(tc.wantAfter, "\n") {
+				t.Errorf("After = %q, want %q", got, strings.Join(tc.wantAfter, "\n"))
+			}
+		})
+	}
+}
+
+func joinLines(lines [][]byte) string {
+	parts := make([]string, len(lines))
+	for i, line := range lines {
+		parts[i] = string(line)
+	}
+	return strings.Join(parts, "\n")
+}
+
+func TestFileScanByteOffsetCRLF(t *testing.T) {
+	// "one\r\ntwo\r\n": "two" starts at byte 5 (3 bytes for "one" + 2 for
+	// its "\r\n", not 4 as len(token)+1 would give by missing the
+	// stripped "\r"), so the "w" it matches on is at absolute offset 6.
+	contents := "one\r\ntwo\r\n"
+	opts := ScanOptions{
+		Patterns: PatternSet{
+			Patterns: map[string]*regexp.Regexp{"w": regexp.MustCompile("w")},
+		},
+	}
+	f := scanFile(t, contents, opts)
+	if f.Error() != nil {
+		t.Fatalf("unexpected scan error: %v", f.Error())
+	}
+
+	m, ok := f.MatchedLines()[2]
+	if !ok {
+		t.Fatalf("expected a match on line 2, got %v", f.MatchedLines())
+	}
+	if m.ByteOffset != 6 {
+		t.Errorf("ByteOffset = %d, want 6", m.ByteOffset)
+	}
+}
+
+func TestFileScanMaxLineBytesOverflow(t *testing.T) {
+	longLine := strings.Repeat("a", 100)
+	contents := "short\n" + longLine + "\n"
+
+	opts := ScanOptions{
+		Patterns: PatternSet{
+			Patterns: map[string]*regexp.Regexp{"a": regexp.MustCompile("a")},
+		},
+		MaxLineBytes: 10,
+	}
+	f := scanFile(t, contents, opts)
+
+	if f.Error() == nil {
+		t.Fatal("expected an error for a line exceeding MaxLineBytes, got none")
+	}
+	if !strings.Contains(f.Error().Error(), "exceeds max line size") {
+		t.Errorf("Error() = %q, want it to mention exceeding the max line size", f.Error())
+	}
+}
@@ -0,0 +1,228 @@
+package file
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Output renders a Heap of scanned files to w in some machine- or
+// human-consumable format. Implementations must not mutate the files
+// they are given beyond what popping the heap requires.
+type Output interface {
+	Write(w io.Writer, files *Heap) error
+}
+
+// GrepOutput renders each match as "path:line:[names] text", where names
+// are the comma-separated pattern names that fired, and each context line
+// as "path-line-text", much like `grep -n`. It is the default output format.
+type GrepOutput struct{}
+
+// Write implements Output.
+func (GrepOutput) Write(w io.Writer, files *Heap) error {
+	for files.Len() > 0 {
+		f, ok := heap.Pop(files).(*File)
+		if !ok {
+			continue
+		}
+
+		for _, lineNumber := range sortedMatchLines(f.matches) {
+			m := f.matches[lineNumber]
+
+			startBefore := lineNumber - len(m.Before)
+			for i, before := range m.Before {
+				if _, err := fmt.Fprintf(w, "%s-%d-%s\n", f.Path(), startBefore+i, before); err != nil {
+					return err
+				}
+			}
+
+			if _, err := fmt.Fprintf(w, "%s:%d:[%s] %s\n", f.Path(), lineNumber, strings.Join(m.Names, ","), m.Text); err != nil {
+				return err
+			}
+
+			for i, after := range m.After {
+				if _, err := fmt.Fprintf(w, "%s-%d-%s\n", f.Path(), lineNumber+1+i, after); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// JSONRecord is a single matched line rendered for JSONOutput.
+type JSONRecord struct {
+	Path       string   `json:"path"`
+	Line       int      `json:"line"`
+	Column     int      `json:"column"`
+	Text       string   `json:"text"`
+	ByteOffset int64    `json:"byteOffset"`
+	Patterns   []string `json:"patterns"`
+	Before     []string `json:"before,omitempty"`
+	After      []string `json:"after,omitempty"`
+}
+
+// JSONOutput renders matches as a JSON array of JSONRecord.
+type JSONOutput struct{}
+
+// Write implements Output.
+func (JSONOutput) Write(w io.Writer, files *Heap) error {
+	records := []JSONRecord{}
+	for files.Len() > 0 {
+		f, ok := heap.Pop(files).(*File)
+		if !ok {
+			continue
+		}
+
+		for _, lineNumber := range sortedMatchLines(f.matches) {
+			m := f.matches[lineNumber]
+			records = append(records, JSONRecord{
+				Path:       f.Path(),
+				Line:       lineNumber,
+				Column:     m.Column,
+				Text:       string(m.Text),
+				ByteOffset: m.ByteOffset,
+				Patterns:   m.Names,
+				Before:     byteLinesToStrings(m.Before),
+				After:      byteLinesToStrings(m.After),
+			})
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+// byteLinesToStrings converts context lines to strings for JSON encoding.
+func byteLinesToStrings(lines [][]byte) []string {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = string(line)
+	}
+	return out
+}
+
+// sarifVersion is the SARIF schema version kpick emits.
+const sarifVersion = "2.1.0"
+
+// sarifSchema is the $schema URI for the SARIF version above.
+const sarifSchema = "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json"
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name string `json:"name"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int          `json:"startLine"`
+	StartColumn int          `json:"startColumn"`
+	Snippet     sarifSnippet `json:"snippet"`
+}
+
+type sarifSnippet struct {
+	Text string `json:"text"`
+}
+
+// SARIFOutput renders matches as a single-run SARIF 2.1.0 log, one Result
+// per matched line, for ingestion by code-scanning UIs.
+type SARIFOutput struct{}
+
+// Write implements Output.
+func (SARIFOutput) Write(w io.Writer, files *Heap) error {
+	run := sarifRun{Tool: sarifTool{Driver: sarifDriver{Name: "kpick"}}}
+
+	for files.Len() > 0 {
+		f, ok := heap.Pop(files).(*File)
+		if !ok {
+			continue
+		}
+
+		for _, lineNumber := range sortedMatchLines(f.matches) {
+			m := f.matches[lineNumber]
+			text := string(m.Text)
+			run.Results = append(run.Results, sarifResult{
+				RuleID:  "kpick/" + strings.Join(m.Names, "+"),
+				Message: sarifMessage{Text: text},
+				Locations: []sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: f.Path()},
+						Region: sarifRegion{
+							StartLine:   lineNumber,
+							StartColumn: m.Column,
+							Snippet:     sarifSnippet{Text: text},
+						},
+					},
+				}},
+			})
+		}
+	}
+
+	log := sarifLog{Version: sarifVersion, Schema: sarifSchema, Runs: []sarifRun{run}}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// OutputFor returns the Output implementation named by format, defaulting
+// to GrepOutput when format is unrecognized or empty.
+func OutputFor(format string) Output {
+	switch format {
+	case "json":
+		return JSONOutput{}
+	case "sarif":
+		return SARIFOutput{}
+	default:
+		return GrepOutput{}
+	}
+}
+
+// PrintFiles writes files to stdout using out, popping files off the heap
+// in ascending path order as it goes.
+func PrintFiles(files *Heap, out Output) error {
+	return out.Write(os.Stdout, files)
+}
@@ -0,0 +1,181 @@
+package file
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// heapOf builds a Heap containing files, already scanned, ready for an
+// Output to consume.
+func heapOf(t *testing.T, files ...*File) *Heap {
+	t.Helper()
+
+	h := &Heap{}
+	heap.Init(h)
+	for _, f := range files {
+		heap.Push(h, f)
+	}
+	return h
+}
+
+func TestOutputForSelectsImplementation(t *testing.T) {
+	cases := []struct {
+		format string
+		want   Output
+	}{
+		{"json", JSONOutput{}},
+		{"sarif", SARIFOutput{}},
+		{"grep", GrepOutput{}},
+		{"", GrepOutput{}},
+		{"bogus", GrepOutput{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.format, func(t *testing.T) {
+			if got := OutputFor(tc.format); got != tc.want {
+				t.Errorf("OutputFor(%q) = %#v, want %#v", tc.format, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestJSONOutputRecordShape(t *testing.T) {
+	opts := ScanOptions{
+		Patterns: PatternSet{
+			Patterns: map[string]*regexp.Regexp{"todo": regexp.MustCompile("TODO")},
+		},
+		ContextBefore: 1,
+		ContextAfter:  1,
+	}
+	f := scanFile(t, "before\n// TODO fix\nafter\n", opts)
+	if f.Error() != nil {
+		t.Fatalf("unexpected scan error: %v", f.Error())
+	}
+
+	var buf bytes.Buffer
+	if err := (JSONOutput{}).Write(&buf, heapOf(t, f)); err != nil {
+		t.Fatal(err)
+	}
+
+	var records []JSONRecord
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+
+	r := records[0]
+	if r.Path != f.Path() {
+		t.Errorf("Path = %q, want %q", r.Path, f.Path())
+	}
+	if r.Line != 2 {
+		t.Errorf("Line = %d, want 2", r.Line)
+	}
+	if len(r.Patterns) != 1 || r.Patterns[0] != "todo" {
+		t.Errorf("Patterns = %v, want [todo]", r.Patterns)
+	}
+	if !strings.Contains(r.Text, "TODO") {
+		t.Errorf("Text = %q, want it to contain TODO", r.Text)
+	}
+	if len(r.Before) != 1 || r.Before[0] != "before" {
+		t.Errorf("Before = %v, want [before]", r.Before)
+	}
+	if len(r.After) != 1 || r.After[0] != "after" {
+		t.Errorf("After = %v, want [after]", r.After)
+	}
+}
+
+func TestSARIFOutputStructure(t *testing.T) {
+	opts := ScanOptions{
+		Patterns: PatternSet{
+			Patterns: map[string]*regexp.Regexp{"todo": regexp.MustCompile("TODO")},
+		},
+	}
+	f := scanFile(t, "// TODO fix\n", opts)
+	if f.Error() != nil {
+		t.Fatalf("unexpected scan error: %v", f.Error())
+	}
+
+	var buf bytes.Buffer
+	if err := (SARIFOutput{}).Write(&buf, heapOf(t, f)); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("output is not valid SARIF JSON: %v\n%s", err, buf.String())
+	}
+
+	if log.Version != sarifVersion {
+		t.Errorf("version = %q, want %q", log.Version, sarifVersion)
+	}
+	if len(log.Runs) != 1 {
+		t.Fatalf("got %d runs, want 1", len(log.Runs))
+	}
+	results := log.Runs[0].Results
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+
+	res := results[0]
+	if res.RuleID != "kpick/todo" {
+		t.Errorf("RuleID = %q, want %q", res.RuleID, "kpick/todo")
+	}
+	if len(res.Locations) != 1 {
+		t.Fatalf("got %d locations, want 1", len(res.Locations))
+	}
+	loc := res.Locations[0].PhysicalLocation
+	if loc.ArtifactLocation.URI != f.Path() {
+		t.Errorf("URI = %q, want %q", loc.ArtifactLocation.URI, f.Path())
+	}
+	if loc.Region.StartLine != 1 {
+		t.Errorf("StartLine = %d, want 1", loc.Region.StartLine)
+	}
+	if !strings.Contains(loc.Region.Snippet.Text, "TODO") {
+		t.Errorf("Snippet.Text = %q, want it to contain TODO", loc.Region.Snippet.Text)
+	}
+}
+
+func TestGrepOutputContextRendering(t *testing.T) {
+	opts := ScanOptions{
+		Patterns: PatternSet{
+			Patterns: map[string]*regexp.Regexp{"todo": regexp.MustCompile("TODO")},
+		},
+		ContextBefore: 1,
+		ContextAfter:  1,
+	}
+	f := scanFile(t, "before\n// TODO fix\nafter\n", opts)
+	if f.Error() != nil {
+		t.Fatalf("unexpected scan error: %v", f.Error())
+	}
+
+	var buf bytes.Buffer
+	if err := (GrepOutput{}).Write(&buf, heapOf(t, f)); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3 (before, match, after):\n%s", len(lines), buf.String())
+	}
+
+	wantBefore := f.Path() + "-1-before"
+	if lines[0] != wantBefore {
+		t.Errorf("before line = %q, want %q", lines[0], wantBefore)
+	}
+
+	wantMatch := f.Path() + ":2:[todo] // TODO fix"
+	if lines[1] != wantMatch {
+		t.Errorf("match line = %q, want %q", lines[1], wantMatch)
+	}
+
+	wantAfter := f.Path() + "-3-after"
+	if lines[2] != wantAfter {
+		t.Errorf("after line = %q, want %q", lines[2], wantAfter)
+	}
+}
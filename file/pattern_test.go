@@ -0,0 +1,55 @@
+package file
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestFileScanMultiplePatternNames(t *testing.T) {
+	opts := ScanOptions{
+		Patterns: PatternSet{
+			Patterns: map[string]*regexp.Regexp{
+				"todo":  regexp.MustCompile("TODO"),
+				"fixme": regexp.MustCompile("FIXME"),
+			},
+		},
+	}
+	f := scanFile(t, "// TODO and FIXME on one line\nplain line\n", opts)
+	if f.Error() != nil {
+		t.Fatalf("unexpected scan error: %v", f.Error())
+	}
+
+	matches := f.MatchedLines()
+	m, ok := matches[1]
+	if !ok {
+		t.Fatalf("expected a match on line 1, got %v", matches)
+	}
+	if got, want := strings.Join(m.Names, ","), "fixme,todo"; got != want {
+		t.Errorf("Names = %q, want %q (sorted)", got, want)
+	}
+	if _, ok := matches[2]; ok {
+		t.Errorf("line 2 matched, want no match")
+	}
+}
+
+func TestFileScanIgnorePattern(t *testing.T) {
+	opts := ScanOptions{
+		Patterns: PatternSet{
+			Patterns: map[string]*regexp.Regexp{"todo": regexp.MustCompile("TODO")},
+			Ignore:   regexp.MustCompile("generated"),
+		},
+	}
+	f := scanFile(t, "// TODO generated file\n// TODO real one\n", opts)
+	if f.Error() != nil {
+		t.Fatalf("unexpected scan error: %v", f.Error())
+	}
+
+	matches := f.MatchedLines()
+	if _, ok := matches[1]; ok {
+		t.Errorf("line 1 matched Ignore pattern but was still reported")
+	}
+	if _, ok := matches[2]; !ok {
+		t.Errorf("expected line 2 to match, got %v", matches)
+	}
+}
@@ -2,9 +2,15 @@ package main
 
 import (
 	"container/heap"
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"runtime"
 
 	"github.com/loganstone/kpick/ask"
 	"github.com/loganstone/kpick/conf"
@@ -12,16 +18,76 @@ import (
 	"github.com/loganstone/kpick/file"
 	"github.com/loganstone/kpick/profile"
 	"github.com/loganstone/kpick/regex"
+	"github.com/loganstone/kpick/server"
 )
 
-func showNumbers(foundFilesCnt int, scanErrorsCnt int, filesCntContainingKorean int) {
+// runServer parses the "server" subcommand's flags from args and starts
+// kpick's HTTP server mode, serving /scan, /healthz and /metrics.
+func runServer(args []string) {
+	fs := flag.NewFlagSet("server", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "address to listen on")
+	maxConcurrentScans := fs.Int("max-concurrent-scans", runtime.NumCPU(), "maximum number of /scan requests served at once")
+	baseDir := fs.String("base-dir", ".", "directory every /scan request's root is confined to")
+	fs.Parse(args)
+
+	srv, err := server.New(*maxConcurrentScans, *baseDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("kpick server listening on %s, confined to %s\n", *addr, *baseDir)
+	fmt.Println("warning: /scan has no authentication of its own; put auth and network isolation in front of this server")
+	log.Fatal(srv.ListenAndServe(*addr))
+}
+
+// defaultPatternName is the name of the always-on Korean-string pattern,
+// kept so kpick's original single-purpose behavior survives unchanged when
+// no -pattern flags are given.
+const defaultPatternName = "korean"
+
+// compilePatterns builds the named pattern set kpick scans with: the
+// built-in Korean pattern plus any user-supplied -pattern name=regex
+// flags, along with the compiled -ignore pattern.
+func compilePatterns(opts conf.Options) (map[string]*regexp.Regexp, *regexp.Regexp, error) {
+	patterns := map[string]*regexp.Regexp{}
+
+	korean, err := regexp.Compile(conf.KoreanPatternForRegex)
+	if err != nil {
+		return nil, nil, err
+	}
+	patterns[defaultPatternName] = korean
+
+	for name, pattern := range opts.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("pattern %q: %w", name, err)
+		}
+		patterns[name] = re
+	}
+
+	var ignore *regexp.Regexp
+	if opts.IgnorePattern != "" {
+		ignore, err = regexp.Compile(opts.IgnorePattern)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return patterns, ignore, nil
+}
+
+func showNumbers(foundFilesCnt int, scanErrorsCnt int, filesCntWithMatches int) {
 	fmt.Printf("[%d] scanning files\n", foundFilesCnt)
 	fmt.Printf("[%d] error \n", scanErrorsCnt)
 	fmt.Printf("[%d] success \n", foundFilesCnt-scanErrorsCnt)
-	fmt.Printf("[%d] files containing korean\n", filesCntContainingKorean)
+	fmt.Printf("[%d] files matching a pattern\n", filesCntWithMatches)
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "server" {
+		runServer(os.Args[2:])
+		return
+	}
+
 	opts := conf.Opts()
 
 	profile.CPU(opts.Cpuprofile)
@@ -37,7 +103,32 @@ func main() {
 	}
 
 	fmt.Printf("find for files [*.%s] in [%s] directory\n", opts.FileExtToScan, opts.DirToFind)
-	foundFiles, err := dir.Find(opts.DirToFind, opts.FileExtToScan, skipPaths)
+	selectFns := []dir.SelectFunc{
+		dir.ExtensionFilter(opts.FileExtToScan),
+		dir.SkipPathFilter(skipPaths),
+	}
+
+	if opts.RespectIgnore {
+		for _, ignoreFile := range []string{".gitignore", ".kpickignore"} {
+			ignoreFilter, err := dir.GitignoreFilter(opts.DirToFind, filepath.Join(opts.DirToFind, ignoreFile))
+			if err != nil {
+				log.Fatal(err)
+			}
+			selectFns = append(selectFns, ignoreFilter)
+		}
+	}
+
+	if opts.MaxFileSize > 0 {
+		selectFns = append(selectFns, dir.MaxSizeFilter(opts.MaxFileSize))
+	}
+
+	if opts.SkipBinary {
+		selectFns = append(selectFns, dir.BinaryFilter())
+	}
+
+	w := dir.NewWalker(opts.DirToFind, selectFns...)
+	w.FollowSymlinks = opts.FollowSymlinks
+	foundFiles, err := w.Walk()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -59,17 +150,17 @@ func main() {
 		}
 	}
 
-	match, ignore, err := regex.ForFileScan(conf.KoreanPatternForRegex, opts.IgnorePattern)
+	patterns, ignore, err := compilePatterns(opts)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	filesContainingKorean := &file.SortedFiles{}
-	heap.Init(filesContainingKorean)
+	filesWithMatches := &file.SortedFiles{}
+	heap.Init(filesWithMatches)
 	var scanErrorsCnt int
 	beforeFn := func(filePath string) {
 		if opts.Verbose {
-			fmt.Printf("[%s] scanning for \"%s\"\n", filePath, match.String())
+			fmt.Printf("[%s] scanning for %d pattern(s)\n", filePath, len(patterns))
 		}
 	}
 	afterFn := func(filePath string) {
@@ -77,27 +168,48 @@ func main() {
 			fmt.Printf("[%s] scanning done\n", filePath)
 		}
 	}
-	for _, paths := range file.Chunks(foundFiles) {
-		for f := range file.ScanFiles(paths, match, ignore, beforeFn, afterFn) {
-			if err := f.Error(); err != nil {
-				scanErrorsCnt++
-				if opts.Verbose || opts.ErrorOnly {
-					fmt.Printf("[%s] scanning error - %s\n", f.Path(), err)
-				}
-				continue
-			}
+	scanOpts := file.ScanOptions{
+		Patterns:      file.PatternSet{Patterns: patterns, Ignore: ignore},
+		MaxLineBytes:  opts.MaxLineBytes,
+		ContextBefore: opts.ContextBefore,
+		ContextAfter:  opts.ContextAfter,
+		Concurrency:   opts.Concurrency,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	defer signal.Stop(sigCh)
+	go func() {
+		if _, ok := <-sigCh; ok {
+			cancel()
+		}
+	}()
 
-			if len(f.FoundLines()) > 0 {
-				heap.Push(filesContainingKorean, f)
+	for f := range file.ScanFiles(ctx, foundFiles, scanOpts, beforeFn, afterFn) {
+		if err := f.Error(); err != nil {
+			scanErrorsCnt++
+			if opts.Verbose || opts.ErrorOnly {
+				fmt.Printf("[%s] scanning error - %s\n", f.Path(), err)
 			}
+			continue
+		}
+
+		if len(f.MatchedLines()) > 0 {
+			heap.Push(filesWithMatches, f)
 		}
 	}
 
 	if !opts.ErrorOnly {
-		file.PrintFiles(filesContainingKorean)
+		out := file.OutputFor(opts.Format)
+		if err := file.PrintFiles(filesWithMatches, out); err != nil {
+			log.Fatal(err)
+		}
 	}
 
-	showNumbers(foundFilesCnt, scanErrorsCnt, filesContainingKorean.Len())
+	showNumbers(foundFilesCnt, scanErrorsCnt, filesWithMatches.Len())
 
 	profile.Mem(opts.Memprofile)
 }
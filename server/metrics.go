@@ -0,0 +1,69 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// metrics holds the Prometheus-style counters handleMetrics exposes.
+type metrics struct {
+	filesScanned uint64
+	matches      uint64
+	errors       uint64
+
+	mu                sync.Mutex
+	scanCount         uint64
+	scanDurationTotal time.Duration
+}
+
+func (m *metrics) incFilesScanned() {
+	atomic.AddUint64(&m.filesScanned, 1)
+}
+
+func (m *metrics) incMatches() {
+	atomic.AddUint64(&m.matches, 1)
+}
+
+func (m *metrics) incErrors() {
+	atomic.AddUint64(&m.errors, 1)
+}
+
+func (m *metrics) observeScanDuration(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scanCount++
+	m.scanDurationTotal += d
+}
+
+func (m *metrics) durationTotals() (count uint64, totalSeconds float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.scanCount, m.scanDurationTotal.Seconds()
+}
+
+// handleMetrics renders the counters in Prometheus text exposition format.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	scanCount, scanDurationSeconds := s.metrics.durationTotals()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	fmt.Fprintln(w, "# HELP kpick_files_scanned_total Total number of files scanned.")
+	fmt.Fprintln(w, "# TYPE kpick_files_scanned_total counter")
+	fmt.Fprintf(w, "kpick_files_scanned_total %d\n", atomic.LoadUint64(&s.metrics.filesScanned))
+
+	fmt.Fprintln(w, "# HELP kpick_matches_total Total number of matched lines.")
+	fmt.Fprintln(w, "# TYPE kpick_matches_total counter")
+	fmt.Fprintf(w, "kpick_matches_total %d\n", atomic.LoadUint64(&s.metrics.matches))
+
+	fmt.Fprintln(w, "# HELP kpick_errors_total Total number of files that failed to scan.")
+	fmt.Fprintln(w, "# TYPE kpick_errors_total counter")
+	fmt.Fprintf(w, "kpick_errors_total %d\n", atomic.LoadUint64(&s.metrics.errors))
+
+	fmt.Fprintln(w, "# HELP kpick_scan_duration_seconds Time spent serving /scan requests.")
+	fmt.Fprintln(w, "# TYPE kpick_scan_duration_seconds summary")
+	fmt.Fprintf(w, "kpick_scan_duration_seconds_sum %f\n", scanDurationSeconds)
+	fmt.Fprintf(w, "kpick_scan_duration_seconds_count %d\n", scanCount)
+}
@@ -0,0 +1,269 @@
+// Package server exposes kpick scans over HTTP so it can be embedded as a
+// code-scan microservice rather than only invoked as a CLI.
+//
+// POST /scan has no authentication of its own: every request's root is
+// confined to the BaseDir a Server is constructed with (see New), but
+// that only stops directory escapes, not unauthorized callers. An
+// operator exposing this outside a single trusted process must put
+// auth (e.g. a reverse proxy) and network isolation in front of it.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/loganstone/kpick/dir"
+	"github.com/loganstone/kpick/file"
+)
+
+// ScanRequest is the JSON body POST /scan accepts.
+type ScanRequest struct {
+	Root       string            `json:"root"`
+	Extensions []string          `json:"extensions"`
+	Skip       []string          `json:"skip"`
+	Patterns   map[string]string `json:"patterns"`
+	Ignore     string            `json:"ignore"`
+}
+
+// ScanResult is a single matched line in a ScanResponse.
+type ScanResult struct {
+	Path       string   `json:"path"`
+	Line       int      `json:"line"`
+	Column     int      `json:"column"`
+	Text       string   `json:"text"`
+	ByteOffset int64    `json:"byteOffset"`
+	Patterns   []string `json:"patterns"`
+}
+
+// ScanResponse is the JSON body POST /scan returns.
+type ScanResponse struct {
+	Results      []ScanResult `json:"results"`
+	FilesScanned int          `json:"filesScanned"`
+	Errors       int          `json:"errors"`
+}
+
+// maxScanRequestBytes bounds how large a POST /scan body may be, so a
+// client can't exhaust server memory with an oversized request.
+const maxScanRequestBytes = 1 << 20 // 1 MiB
+
+// Server serves kpick scans over HTTP, bounding how many scans run at once
+// and which directory a scan's root may resolve to.
+type Server struct {
+	baseDir string
+	sem     chan struct{}
+	metrics *metrics
+}
+
+// New returns a Server that allows at most maxConcurrentScans scans to run
+// at once (maxConcurrentScans <= 0 defaults to runtime.NumCPU()) and
+// confines every request's root to baseDir, rejecting any root that would
+// resolve outside it.
+func New(maxConcurrentScans int, baseDir string) (*Server, error) {
+	if maxConcurrentScans <= 0 {
+		maxConcurrentScans = runtime.NumCPU()
+	}
+
+	absBaseDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvedBaseDir, err := filepath.EvalSymlinks(absBaseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		baseDir: resolvedBaseDir,
+		sem:     make(chan struct{}, maxConcurrentScans),
+		metrics: &metrics{},
+	}, nil
+}
+
+// Handler returns the http.Handler serving /scan, /healthz and /metrics.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/scan", s.handleScan)
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	return mux
+}
+
+// ListenAndServe starts the HTTP server on addr.
+func (s *Server) ListenAndServe(addr string) error {
+	return http.ListenAndServe(addr, s.Handler())
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "ok")
+}
+
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST required", http.StatusMethodNotAllowed)
+		return
+	}
+
+	select {
+	case s.sem <- struct{}{}:
+		defer func() { <-s.sem }()
+	default:
+		http.Error(w, "too many concurrent scans", http.StatusTooManyRequests)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxScanRequestBytes)
+
+	var req ScanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := s.scan(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// withinBaseDir reports whether path is s.baseDir itself or a descendant
+// of it. Callers must pass an absolute, already-symlink-resolved path for
+// this check to mean anything.
+func (s *Server) withinBaseDir(path string) bool {
+	return path == s.baseDir || strings.HasPrefix(path, s.baseDir+string(filepath.Separator))
+}
+
+// scan walks req.Root for matching files and scans them for req.Patterns,
+// stopping early if ctx is cancelled (e.g. the HTTP client disconnects).
+func (s *Server) scan(ctx context.Context, req ScanRequest) (*ScanResponse, error) {
+	start := time.Now()
+	defer func() { s.metrics.observeScanDuration(time.Since(start)) }()
+
+	reqRoot := req.Root
+	if reqRoot == "" {
+		reqRoot = "."
+	}
+
+	root := filepath.Join(s.baseDir, reqRoot)
+	if !s.withinBaseDir(root) {
+		return nil, fmt.Errorf("root %q escapes the server's base directory", req.Root)
+	}
+
+	if err := dir.Check(root); err != nil {
+		return nil, err
+	}
+
+	// root may still reach outside baseDir through a symlink (e.g. a
+	// checkout of an untrusted repo containing one); re-check against the
+	// resolved path, not just the lexical join above.
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+	if !s.withinBaseDir(resolvedRoot) {
+		return nil, fmt.Errorf("root %q escapes the server's base directory", req.Root)
+	}
+	root = resolvedRoot
+
+	var selectFns []dir.SelectFunc
+	if len(req.Extensions) > 0 {
+		extFilters := make([]dir.SelectFunc, len(req.Extensions))
+		for i, ext := range req.Extensions {
+			extFilters[i] = dir.ExtensionFilter(ext)
+		}
+		selectFns = append(selectFns, dir.Or(extFilters...))
+	}
+
+	if len(req.Skip) > 0 {
+		skipRes := make([]*regexp.Regexp, len(req.Skip))
+		for i, pattern := range req.Skip {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("skip pattern %q: %w", pattern, err)
+			}
+			skipRes[i] = re
+		}
+		selectFns = append(selectFns, dir.SkipPathFilter(skipRes))
+	}
+
+	paths, err := dir.NewWalker(root, selectFns...).Walk()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Patterns) == 0 {
+		return nil, fmt.Errorf("patterns: at least one name=regex pair is required")
+	}
+
+	patterns := make(map[string]*regexp.Regexp, len(req.Patterns))
+	for name, pattern := range req.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", name, err)
+		}
+		patterns[name] = re
+	}
+
+	var ignore *regexp.Regexp
+	if req.Ignore != "" {
+		ignore, err = regexp.Compile(req.Ignore)
+		if err != nil {
+			return nil, fmt.Errorf("ignore pattern: %w", err)
+		}
+	}
+
+	scanOpts := file.ScanOptions{
+		Patterns: file.PatternSet{Patterns: patterns, Ignore: ignore},
+	}
+
+	noop := func(string) {}
+
+	resp := &ScanResponse{}
+	for f := range file.ScanFiles(ctx, paths, scanOpts, noop, noop) {
+		resp.FilesScanned++
+		s.metrics.incFilesScanned()
+
+		if err := f.Error(); err != nil {
+			resp.Errors++
+			s.metrics.incErrors()
+			continue
+		}
+
+		matches := f.MatchedLines()
+		lineNumbers := make([]int, 0, len(matches))
+		for lineNumber := range matches {
+			lineNumbers = append(lineNumbers, lineNumber)
+		}
+		sort.Ints(lineNumbers)
+
+		for _, lineNumber := range lineNumbers {
+			m := matches[lineNumber]
+			resp.Results = append(resp.Results, ScanResult{
+				Path:       f.Path(),
+				Line:       lineNumber,
+				Column:     m.Column,
+				Text:       string(m.Text),
+				ByteOffset: m.ByteOffset,
+				Patterns:   m.Names,
+			})
+			s.metrics.incMatches()
+		}
+	}
+
+	return resp, nil
+}
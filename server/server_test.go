@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestServer(t *testing.T, baseDir string) *Server {
+	t.Helper()
+
+	srv, err := New(1, baseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
+
+func TestScanRejectsRootEscapingBaseDir(t *testing.T) {
+	base := t.TempDir()
+	if err := os.Mkdir(filepath.Join(base, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		root string
+	}{
+		{"parent traversal", "../"},
+		{"deep parent traversal", "../../../../etc"},
+		{"absolute path outside base", "/etc"},
+	}
+
+	srv := newTestServer(t, filepath.Join(base, "sub"))
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := ScanRequest{
+				Root:     tc.root,
+				Patterns: map[string]string{"any": ".*"},
+			}
+			if _, err := srv.scan(context.Background(), req); err == nil {
+				t.Errorf("scan(root=%q) succeeded, want a root-escape error", tc.root)
+			}
+		})
+	}
+}
+
+func TestScanRejectsSymlinkEscapingBaseDir(t *testing.T) {
+	base := t.TempDir()
+	baseSub := filepath.Join(base, "sub")
+	if err := os.Mkdir(baseSub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.go"), []byte("// TODO leaked secret\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(outside, filepath.Join(baseSub, "escape")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	srv := newTestServer(t, baseSub)
+	req := ScanRequest{
+		Root:     "escape",
+		Patterns: map[string]string{"todo": "TODO"},
+	}
+
+	if _, err := srv.scan(context.Background(), req); err == nil {
+		t.Error("scan through a symlink escaping baseDir succeeded, want a root-escape error")
+	}
+}
+
+func TestScanAllowsRootWithinBaseDir(t *testing.T) {
+	base := t.TempDir()
+	if err := os.WriteFile(filepath.Join(base, "a.go"), []byte("// TODO fix this\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := newTestServer(t, base)
+	req := ScanRequest{
+		Root:     ".",
+		Patterns: map[string]string{"todo": "TODO"},
+	}
+
+	resp, err := srv.scan(context.Background(), req)
+	if err != nil {
+		t.Fatalf("scan returned an error: %v", err)
+	}
+	if resp.FilesScanned != 1 {
+		t.Errorf("FilesScanned = %d, want 1", resp.FilesScanned)
+	}
+	if len(resp.Results) != 1 {
+		t.Fatalf("Results = %v, want exactly one match", resp.Results)
+	}
+	if resp.Results[0].Patterns[0] != "todo" {
+		t.Errorf("Patterns = %v, want [todo]", resp.Results[0].Patterns)
+	}
+}
+
+func TestScanRequiresAtLeastOnePattern(t *testing.T) {
+	base := t.TempDir()
+	srv := newTestServer(t, base)
+
+	if _, err := srv.scan(context.Background(), ScanRequest{Root: "."}); err == nil {
+		t.Error("scan with no patterns succeeded, want an error")
+	}
+}